@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+)
+
+// clusterMetrics is a single Aurora cluster's fetched storage data.
+type clusterMetrics struct {
+	clusterID                 string
+	region                    string
+	accountID                 string
+	storageType               string
+	engineVersion             string
+	allocatedStorageGigabytes float64
+
+	tagLabelValues []string
+}
+
+// fetchClusterMetrics scrapes a single AWS session's Aurora clusters,
+// applying the same InstanceFilter used for RDS instances so
+// --exclude-instance-regex/--include-tag/--include-engine scope Aurora
+// clusters too. The returned error reflects only the top-level
+// DescribeDBClusters call, so the collector can tell a total outage
+// (keep serving the cache) apart from a legitimately empty result.
+func fetchClusterMetrics(ctx context.Context, sess *awsSession, filter *InstanceFilter) ([]clusterMetrics, error) {
+	resp, err := sess.rdsSvc.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{})
+	if err != nil {
+		log.Printf("unable to describe DB clusters in region %s: %v", sess.Region, err)
+		return nil, err
+	}
+
+	metrics := make([]clusterMetrics, 0, len(resp.DBClusters))
+	for _, cluster := range resp.DBClusters {
+		include, tagLabelValues := filter.MatchCluster(sess, cluster)
+		if !include {
+			continue
+		}
+
+		metrics = append(metrics, clusterMetrics{
+			clusterID:                 aws.ToString(cluster.DBClusterIdentifier),
+			region:                    sess.Region,
+			accountID:                 sess.AccountID,
+			storageType:               aws.ToString(cluster.StorageType),
+			engineVersion:             aws.ToString(cluster.EngineVersion),
+			allocatedStorageGigabytes: float64(aws.ToInt32(cluster.AllocatedStorage)),
+			tagLabelValues:            tagLabelValues,
+		})
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const bytesPerGigabyte = 1073741824
+
+// fetchTimeout bounds the whole scrape cycle (DescribeDBInstances,
+// GetMetricData, DescribeDBClusters, DescribeEvents, across every
+// session) so a hung or slow AWS account can't block a /metrics request
+// indefinitely.
+const fetchTimeout = 30 * time.Second
+
+// instanceMetrics is a single RDS instance's fetched storage data, along
+// with the outcome of the CloudWatch call that produced it.
+type instanceMetrics struct {
+	instanceID string
+	region     string
+	accountID  string
+
+	hasMaxAllocated           bool
+	maxAllocatedGigabytes     float64
+	freeStorageBytes          float64
+	allocatedStorageGigabytes float64
+	usedStorageGigabytes      float64
+	storageUtilizationRatio   float64
+
+	scrapeDurationSeconds float64
+	scrapeError           bool
+
+	tagLabelValues []string
+}
+
+// scrapeErrorTally accumulates the cumulative number of failed scrapes for
+// one label set, so rds_storage_exporter_scrape_errors_total behaves like
+// a proper Prometheus counter instead of resetting to 0 on the next
+// successful scrape.
+type scrapeErrorTally struct {
+	labels []string
+	count  float64
+}
+
+// RDSStorageCollector implements prometheus.Collector, fetching storage
+// metrics from RDS/CloudWatch on every scrape rather than on a background
+// timer. Results are cached for cacheTTL so that multiple scrapes in quick
+// succession don't multiply AWS API cost.
+type RDSStorageCollector struct {
+	sessions []*awsSession
+	cacheTTL time.Duration
+	filter   *InstanceFilter
+
+	mu             sync.Mutex
+	cachedAt       time.Time
+	cachedMetrics  []instanceMetrics
+	cachedClusters []clusterMetrics
+
+	seenEvents   map[string]struct{}
+	eventTallies map[string]*eventTally
+
+	scrapeErrorTallies map[string]*scrapeErrorTally
+
+	maxAllocatedStorageDesc       *prometheus.Desc
+	freeStorageBytesDesc          *prometheus.Desc
+	allocatedStorageGigabytesDesc *prometheus.Desc
+	usedStorageGigabytesDesc      *prometheus.Desc
+	storageUtilizationRatioDesc   *prometheus.Desc
+
+	scrapeDurationSecondsDesc *prometheus.Desc
+	scrapeErrorsTotalDesc     *prometheus.Desc
+	scrapeSuccessDesc         *prometheus.Desc
+
+	clusterAllocatedStorageDesc *prometheus.Desc
+	autoscalingEventsTotalDesc  *prometheus.Desc
+}
+
+// NewRDSStorageCollector builds a collector that scrapes the given AWS
+// sessions, caching results for cacheTTL and scoping/labeling instances
+// per filter.
+func NewRDSStorageCollector(sessions []*awsSession, cacheTTL time.Duration, filter *InstanceFilter) *RDSStorageCollector {
+	labels := append([]string{"instance", "region", "account_id"}, filter.LabelKeys()...)
+	clusterLabels := append([]string{"cluster", "region", "account_id", "storage_type", "engine_version"}, filter.LabelKeys()...)
+	eventLabels := append([]string{"instance", "event_type"}, filter.LabelKeys()...)
+
+	return &RDSStorageCollector{
+		sessions: sessions,
+		cacheTTL: cacheTTL,
+		filter:   filter,
+
+		seenEvents:   make(map[string]struct{}),
+		eventTallies: make(map[string]*eventTally),
+
+		scrapeErrorTallies: make(map[string]*scrapeErrorTally),
+
+		maxAllocatedStorageDesc: prometheus.NewDesc(
+			"rds_max_allocated_storage_gigabytes",
+			"Maximum storage (in gigabytes) that RDS instance can auto-scale to.",
+			labels, nil,
+		),
+		freeStorageBytesDesc: prometheus.NewDesc(
+			"rds_free_storage_bytes",
+			"Free storage space of the RDS instance in bytes, as reported by CloudWatch FreeStorageSpace.",
+			labels, nil,
+		),
+		allocatedStorageGigabytesDesc: prometheus.NewDesc(
+			"rds_allocated_storage_gigabytes",
+			"Storage allocated to the RDS instance in gigabytes.",
+			labels, nil,
+		),
+		usedStorageGigabytesDesc: prometheus.NewDesc(
+			"rds_used_storage_gigabytes",
+			"Storage used by the RDS instance in gigabytes, derived from AllocatedStorage minus FreeStorageSpace.",
+			labels, nil,
+		),
+		storageUtilizationRatioDesc: prometheus.NewDesc(
+			"rds_storage_utilization_ratio",
+			"Ratio of used storage to max allocated storage (falls back to allocated storage when auto-scaling is disabled).",
+			labels, nil,
+		),
+		scrapeDurationSecondsDesc: prometheus.NewDesc(
+			"rds_storage_exporter_scrape_duration_seconds",
+			"Time taken to fetch storage metrics for an RDS instance.",
+			labels, nil,
+		),
+		scrapeErrorsTotalDesc: prometheus.NewDesc(
+			"rds_storage_exporter_scrape_errors_total",
+			"Cumulative number of failed metric scrapes for an RDS instance.",
+			labels, nil,
+		),
+		scrapeSuccessDesc: prometheus.NewDesc(
+			"rds_storage_exporter_scrape_success",
+			"Whether the last scrape for an RDS instance succeeded (1) or failed (0).",
+			labels, nil,
+		),
+		clusterAllocatedStorageDesc: prometheus.NewDesc(
+			"rds_cluster_allocated_storage_gigabytes",
+			"Storage allocated to the Aurora cluster in gigabytes.",
+			clusterLabels, nil,
+		),
+		autoscalingEventsTotalDesc: prometheus.NewDesc(
+			"rds_storage_autoscaling_events_total",
+			"Number of times RDS has auto-scaled an instance's storage.",
+			eventLabels, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *RDSStorageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxAllocatedStorageDesc
+	ch <- c.freeStorageBytesDesc
+	ch <- c.allocatedStorageGigabytesDesc
+	ch <- c.usedStorageGigabytesDesc
+	ch <- c.storageUtilizationRatioDesc
+	ch <- c.scrapeDurationSecondsDesc
+	ch <- c.scrapeErrorsTotalDesc
+	ch <- c.scrapeSuccessDesc
+	ch <- c.clusterAllocatedStorageDesc
+	ch <- c.autoscalingEventsTotalDesc
+}
+
+// Collect implements prometheus.Collector, fetching from RDS/CloudWatch
+// (or serving from cache, if still fresh) on every call.
+func (c *RDSStorageCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.fetch() {
+		labels := append([]string{m.instanceID, m.region, m.accountID}, m.tagLabelValues...)
+
+		if m.hasMaxAllocated {
+			ch <- prometheus.MustNewConstMetric(c.maxAllocatedStorageDesc, prometheus.GaugeValue, m.maxAllocatedGigabytes, labels...)
+		}
+		ch <- prometheus.MustNewConstMetric(c.freeStorageBytesDesc, prometheus.GaugeValue, m.freeStorageBytes, labels...)
+		ch <- prometheus.MustNewConstMetric(c.allocatedStorageGigabytesDesc, prometheus.GaugeValue, m.allocatedStorageGigabytes, labels...)
+		ch <- prometheus.MustNewConstMetric(c.usedStorageGigabytesDesc, prometheus.GaugeValue, m.usedStorageGigabytes, labels...)
+		ch <- prometheus.MustNewConstMetric(c.storageUtilizationRatioDesc, prometheus.GaugeValue, m.storageUtilizationRatio, labels...)
+
+		ch <- prometheus.MustNewConstMetric(c.scrapeDurationSecondsDesc, prometheus.GaugeValue, m.scrapeDurationSeconds, labels...)
+		ch <- prometheus.MustNewConstMetric(c.scrapeSuccessDesc, prometheus.GaugeValue, boolToFloat64(!m.scrapeError), labels...)
+	}
+
+	c.mu.Lock()
+	clusters := c.cachedClusters
+	eventTallies := make([]*eventTally, 0, len(c.eventTallies))
+	for _, tally := range c.eventTallies {
+		eventTallies = append(eventTallies, tally)
+	}
+	scrapeErrorTallies := make([]*scrapeErrorTally, 0, len(c.scrapeErrorTallies))
+	for _, tally := range c.scrapeErrorTallies {
+		scrapeErrorTallies = append(scrapeErrorTallies, tally)
+	}
+	c.mu.Unlock()
+
+	for _, tally := range scrapeErrorTallies {
+		ch <- prometheus.MustNewConstMetric(c.scrapeErrorsTotalDesc, prometheus.CounterValue, tally.count, tally.labels...)
+	}
+
+	for _, cm := range clusters {
+		labels := append([]string{cm.clusterID, cm.region, cm.accountID, cm.storageType, cm.engineVersion}, cm.tagLabelValues...)
+		ch <- prometheus.MustNewConstMetric(c.clusterAllocatedStorageDesc, prometheus.GaugeValue, cm.allocatedStorageGigabytes, labels...)
+	}
+
+	for _, tally := range eventTallies {
+		ch <- prometheus.MustNewConstMetric(c.autoscalingEventsTotalDesc, prometheus.CounterValue, tally.count, tally.labels...)
+	}
+}
+
+// fetch returns the cached metric snapshot if it's younger than cacheTTL,
+// otherwise it scrapes every session fresh and repopulates the cache. The
+// network round trip runs with mu released so a slow or hung AWS account
+// blocks only this fetch, not every concurrent /metrics request reading
+// the cache.
+func (c *RDSStorageCollector) fetch() []instanceMetrics {
+	c.mu.Lock()
+	fresh := c.cacheTTL > 0 && time.Since(c.cachedAt) < c.cacheTTL
+	cached := c.cachedMetrics
+	c.mu.Unlock()
+
+	if fresh {
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	var (
+		wg               sync.WaitGroup
+		mu               sync.Mutex
+		metrics          []instanceMetrics
+		clusters         []clusterMetrics
+		events           []storageAutoscaleEvent
+		instanceFailures int
+		clusterFailures  int
+	)
+
+	for _, sess := range c.sessions {
+		wg.Add(1)
+		go func(sess *awsSession) {
+			defer wg.Done()
+			sessionMetrics, instanceErr := fetchSessionMetrics(ctx, sess, c.filter)
+			sessionClusters, clusterErr := fetchClusterMetrics(ctx, sess, c.filter)
+			sessionEvents := fetchStorageAutoscaleEvents(ctx, sess, c.filter)
+
+			mu.Lock()
+			if instanceErr != nil {
+				instanceFailures++
+			}
+			if clusterErr != nil {
+				clusterFailures++
+			}
+			metrics = append(metrics, sessionMetrics...)
+			clusters = append(clusters, sessionClusters...)
+			events = append(events, sessionEvents...)
+			mu.Unlock()
+		}(sess)
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	if len(c.sessions) == 0 || instanceFailures < len(c.sessions) {
+		c.cachedMetrics = metrics
+	} else {
+		log.Printf("all %d session(s) failed to describe DB instances; serving last-known-good metrics", len(c.sessions))
+	}
+	if len(c.sessions) == 0 || clusterFailures < len(c.sessions) {
+		c.cachedClusters = clusters
+	} else {
+		log.Printf("all %d session(s) failed to describe DB clusters; serving last-known-good clusters", len(c.sessions))
+	}
+	c.cachedAt = time.Now()
+	c.recordEvents(events)
+	c.recordScrapeErrors(metrics)
+	cached = c.cachedMetrics
+	c.mu.Unlock()
+
+	return cached
+}
+
+// recordScrapeErrors folds this fetch's failures into the running
+// per-label-set counters, so scrape failures accumulate instead of
+// resetting to 0 the moment a later scrape succeeds.
+func (c *RDSStorageCollector) recordScrapeErrors(metrics []instanceMetrics) {
+	for _, m := range metrics {
+		if !m.scrapeError {
+			continue
+		}
+
+		labels := append([]string{m.instanceID, m.region, m.accountID}, m.tagLabelValues...)
+		key := strings.Join(labels, eventKeySeparator)
+
+		tally, ok := c.scrapeErrorTallies[key]
+		if !ok {
+			tally = &scrapeErrorTally{labels: labels}
+			c.scrapeErrorTallies[key] = tally
+		}
+		tally.count++
+	}
+}
+
+// eventTally accumulates the cumulative number of autoscaling events for
+// one label set, the same pattern scrapeErrorTally uses for
+// rds_storage_exporter_scrape_errors_total.
+type eventTally struct {
+	labels []string
+	count  float64
+}
+
+// recordEvents folds newly observed autoscaling events into the running
+// per-label-set counters, skipping any event already counted on a prior
+// fetch so overlapping lookback windows don't double-count it.
+func (c *RDSStorageCollector) recordEvents(events []storageAutoscaleEvent) {
+	for _, ev := range events {
+		dedupeKey := ev.instanceID + "|" + ev.eventType + "|" + ev.sourceARN + "|" + ev.occurredAt
+		if _, ok := c.seenEvents[dedupeKey]; ok {
+			continue
+		}
+		c.seenEvents[dedupeKey] = struct{}{}
+
+		labels := append([]string{ev.instanceID, ev.eventType}, ev.tagLabelValues...)
+		key := strings.Join(labels, eventKeySeparator)
+
+		tally, ok := c.eventTallies[key]
+		if !ok {
+			tally = &eventTally{labels: labels}
+			c.eventTallies[key] = tally
+		}
+		tally.count++
+	}
+}
+
+const eventKeySeparator = "\x1f"
+
+// fetchSessionMetrics scrapes a single AWS session's RDS instances,
+// returning one instanceMetrics per instance that passes filter
+// (regardless of per-instance scrape errors, so one bad instance doesn't
+// drop the rest). The returned error reflects only the top-level
+// DescribeDBInstances call, so the collector can tell a total outage
+// (keep serving the cache) apart from a legitimately empty result.
+func fetchSessionMetrics(ctx context.Context, sess *awsSession, filter *InstanceFilter) ([]instanceMetrics, error) {
+	resp, err := sess.rdsSvc.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{})
+	if err != nil {
+		log.Printf("unable to describe DB instances in region %s: %v", sess.Region, err)
+		return nil, err
+	}
+
+	metrics := make([]instanceMetrics, 0, len(resp.DBInstances))
+	for _, dbInstance := range resp.DBInstances {
+		include, tagLabelValues := filter.Match(sess, dbInstance)
+		if !include {
+			continue
+		}
+		metrics = append(metrics, fetchInstanceMetrics(ctx, sess, dbInstance, tagLabelValues))
+	}
+
+	return metrics, nil
+}
+
+func fetchInstanceMetrics(ctx context.Context, sess *awsSession, dbInstance rdstypes.DBInstance, tagLabelValues []string) instanceMetrics {
+	instanceID := aws.ToString(dbInstance.DBInstanceIdentifier)
+	m := instanceMetrics{
+		instanceID:     instanceID,
+		region:         sess.Region,
+		accountID:      sess.AccountID,
+		tagLabelValues: tagLabelValues,
+	}
+
+	start := time.Now()
+
+	metricData, err := sess.cwSvc.GetMetricData(ctx, &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(time.Now().Add(-3 * time.Hour)),
+		EndTime:   aws.Time(time.Now()),
+		MetricDataQueries: []types.MetricDataQuery{
+			{
+				Id: aws.String("m1"),
+				MetricStat: &types.MetricStat{
+					Metric: &types.Metric{
+						Namespace:  aws.String("AWS/RDS"),
+						MetricName: aws.String("FreeStorageSpace"),
+						Dimensions: []types.Dimension{
+							{
+								Name:  aws.String("DBInstanceIdentifier"),
+								Value: dbInstance.DBInstanceIdentifier,
+							},
+						},
+					},
+					Period: aws.Int32(3600),
+					Stat:   aws.String("Average"),
+				},
+			},
+		},
+	})
+
+	m.scrapeDurationSeconds = time.Since(start).Seconds()
+
+	if err != nil {
+		log.Printf("unable to get metric data for instance %s: %v", instanceID, err)
+		m.scrapeError = true
+		return m
+	}
+
+	if len(metricData.MetricDataResults) == 0 || len(metricData.MetricDataResults[0].Values) == 0 {
+		log.Printf("no metric data found for instance %s", instanceID)
+		m.scrapeError = true
+		return m
+	}
+
+	freeBytes := metricData.MetricDataResults[0].Values[0]
+	allocatedGigabytes := float64(aws.ToInt32(dbInstance.AllocatedStorage))
+
+	maxAllocatedGigabytes := allocatedGigabytes
+	if dbInstance.MaxAllocatedStorage != nil {
+		maxAllocatedGigabytes = float64(aws.ToInt32(dbInstance.MaxAllocatedStorage))
+		m.hasMaxAllocated = true
+		m.maxAllocatedGigabytes = maxAllocatedGigabytes
+	}
+
+	usedGigabytes, utilizationRatio := calculateStorageUsage(allocatedGigabytes, freeBytes, maxAllocatedGigabytes)
+
+	m.freeStorageBytes = freeBytes
+	m.allocatedStorageGigabytes = allocatedGigabytes
+	m.usedStorageGigabytes = usedGigabytes
+	m.storageUtilizationRatio = utilizationRatio
+
+	return m
+}
+
+// calculateStorageUsage derives used storage and utilization ratio from
+// an instance's allocated storage, CloudWatch free storage bytes, and its
+// effective max allocated storage (pre-resolved by the caller to fall
+// back to allocated storage when auto-scaling is disabled).
+func calculateStorageUsage(allocatedGigabytes, freeBytes, maxAllocatedGigabytes float64) (usedGigabytes, utilizationRatio float64) {
+	usedGigabytes = allocatedGigabytes - freeBytes/bytesPerGigabyte
+	utilizationRatio = usedGigabytes / maxAllocatedGigabytes
+	return usedGigabytes, utilizationRatio
+}
+
+func boolToFloat64(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestCalculateStorageUsage(t *testing.T) {
+	tests := []struct {
+		name                  string
+		allocatedGigabytes    float64
+		freeBytes             float64
+		maxAllocatedGigabytes float64
+		wantUsedGigabytes     float64
+		wantUtilizationRatio  float64
+	}{
+		{
+			name:                  "half of allocated storage free",
+			allocatedGigabytes:    100,
+			freeBytes:             50 * bytesPerGigabyte,
+			maxAllocatedGigabytes: 100,
+			wantUsedGigabytes:     50,
+			wantUtilizationRatio:  0.5,
+		},
+		{
+			name:                  "autoscaling enabled, max larger than allocated",
+			allocatedGigabytes:    100,
+			freeBytes:             20 * bytesPerGigabyte,
+			maxAllocatedGigabytes: 200,
+			wantUsedGigabytes:     80,
+			wantUtilizationRatio:  0.4,
+		},
+		{
+			name:                  "no free space reported",
+			allocatedGigabytes:    100,
+			freeBytes:             0,
+			maxAllocatedGigabytes: 100,
+			wantUsedGigabytes:     100,
+			wantUtilizationRatio:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUsed, gotRatio := calculateStorageUsage(tt.allocatedGigabytes, tt.freeBytes, tt.maxAllocatedGigabytes)
+			if gotUsed != tt.wantUsedGigabytes {
+				t.Errorf("usedGigabytes = %v, want %v", gotUsed, tt.wantUsedGigabytes)
+			}
+			if gotRatio != tt.wantUtilizationRatio {
+				t.Errorf("utilizationRatio = %v, want %v", gotRatio, tt.wantUtilizationRatio)
+			}
+		})
+	}
+}
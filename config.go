@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SessionConfig describes a single AWS account/region pair to scrape.
+// Instances discovered through this session are labeled with its region
+// and the account ID resolved via STS.
+type SessionConfig struct {
+	Region     string `yaml:"region"`
+	RoleARN    string `yaml:"role_arn"`
+	ExternalID string `yaml:"external_id"`
+	Profile    string `yaml:"profile"`
+}
+
+// Config is the top-level exporter configuration, loaded from a YAML file
+// via the --config flag. It supports scraping many AWS accounts and
+// regions from a single exporter process.
+type Config struct {
+	Sessions []SessionConfig `yaml:"sessions"`
+}
+
+// loadConfigFile reads and parses the exporter's YAML config file.
+func loadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse config file %s: %w", path, err)
+	}
+
+	if len(cfg.Sessions) == 0 {
+		return nil, fmt.Errorf("config file %s defines no sessions", path)
+	}
+
+	return &cfg, nil
+}
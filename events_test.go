@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+func TestIsStorageAutoscaleEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    bool
+	}{
+		{
+			name:    "storage actually increased",
+			message: "Allocated storage has been increased from 100GB to 120GB.",
+			want:    true,
+		},
+		{
+			name:    "storage increased, alternate wording",
+			message: "RDS has increased the storage size of your instance from 50 GB to 60 GB.",
+			want:    true,
+		},
+		{
+			name:    "autoscaling disabled notification should not count",
+			message: "Storage autoscaling has been disabled for this instance.",
+			want:    false,
+		},
+		{
+			name:    "max threshold reached without an actual increase",
+			message: "Storage autoscaling reached the maximum configured threshold.",
+			want:    false,
+		},
+		{
+			name:    "mentions storage and autoscaling but no size transition",
+			message: "Storage autoscaling is monitoring this instance and may increase its storage if needed.",
+			want:    false,
+		},
+		{
+			name:    "unrelated configuration change",
+			message: "Parameter group has been updated.",
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := rdstypes.Event{Message: aws.String(tt.message)}
+			if got := isStorageAutoscaleEvent(ev); got != tt.want {
+				t.Errorf("isStorageAutoscaleEvent(%q) = %v, want %v", tt.message, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+// tagFilter is a single --include-tag key=value requirement; an instance
+// must carry this exact tag to be scraped.
+type tagFilter struct {
+	Key   string
+	Value string
+}
+
+// tagFilterFlag accumulates repeated "--include-tag key=value" flags into
+// a slice of tagFilters, implementing flag.Value.
+type tagFilterFlag []tagFilter
+
+func (f *tagFilterFlag) String() string {
+	return fmt.Sprint([]tagFilter(*f))
+}
+
+func (f *tagFilterFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("invalid --include-tag %q, expected key=value", value)
+	}
+	*f = append(*f, tagFilter{Key: key, Value: val})
+	return nil
+}
+
+// InstanceFilter scopes which RDS instances are scraped (by tag, engine,
+// and identifier regex) and which tag keys are propagated as Prometheus
+// labels on every emitted series. Tags are fetched via ListTagsForResource
+// and cached by instance ARN so repeated scrapes don't re-fetch them.
+type InstanceFilter struct {
+	includeTags          []tagFilter
+	excludeInstanceRegex *regexp.Regexp
+	includeEngines       map[string]struct{}
+	labelTagKeys         []string
+
+	mu       sync.Mutex
+	tagCache map[string]map[string]string
+}
+
+// NewInstanceFilter builds an InstanceFilter from parsed CLI flags.
+func NewInstanceFilter(includeTags []tagFilter, excludeInstanceRegex *regexp.Regexp, includeEngines, labelTagKeys []string) *InstanceFilter {
+	engines := make(map[string]struct{}, len(includeEngines))
+	for _, engine := range includeEngines {
+		engine = strings.ToLower(strings.TrimSpace(engine))
+		if engine != "" {
+			engines[engine] = struct{}{}
+		}
+	}
+
+	return &InstanceFilter{
+		includeTags:          includeTags,
+		excludeInstanceRegex: excludeInstanceRegex,
+		includeEngines:       engines,
+		labelTagKeys:         labelTagKeys,
+		tagCache:             make(map[string]map[string]string),
+	}
+}
+
+// LabelKeys returns the extra Prometheus label names this filter adds, in
+// the order their values are returned from Match.
+func (f *InstanceFilter) LabelKeys() []string {
+	return f.labelTagKeys
+}
+
+// Match reports whether dbInstance passes the configured tag/engine/regex
+// filters and, if so, the label values (in LabelKeys order) to propagate.
+func (f *InstanceFilter) Match(sess *awsSession, dbInstance rdstypes.DBInstance) (bool, []string) {
+	return f.match(sess,
+		aws.ToString(dbInstance.DBInstanceIdentifier),
+		aws.ToString(dbInstance.Engine),
+		aws.ToString(dbInstance.DBInstanceArn),
+	)
+}
+
+// MatchCluster applies the same tag/engine/regex filters as Match to an
+// Aurora cluster, so rds_cluster_allocated_storage_gigabytes is scoped
+// and labeled the same way --exclude-instance-regex/--include-tag/
+// --include-engine scope the per-instance metrics.
+func (f *InstanceFilter) MatchCluster(sess *awsSession, cluster rdstypes.DBCluster) (bool, []string) {
+	return f.match(sess,
+		aws.ToString(cluster.DBClusterIdentifier),
+		aws.ToString(cluster.Engine),
+		aws.ToString(cluster.DBClusterArn),
+	)
+}
+
+// MatchEvent applies the identifier-exclusion and tag-label filters to an
+// autoscaling event's source instance. RDS events don't report an engine,
+// so --include-engine can't be applied here and is intentionally skipped;
+// --exclude-instance-regex and --include-tag/--label-tags still apply.
+func (f *InstanceFilter) MatchEvent(sess *awsSession, sourceIdentifier, sourceARN string) (bool, []string) {
+	if f.excludeInstanceRegex != nil && f.excludeInstanceRegex.MatchString(sourceIdentifier) {
+		return false, nil
+	}
+
+	return f.matchTags(f.tagsForARN(sess, sourceARN))
+}
+
+// match is the engine/regex/tag filter shared by Match and MatchCluster.
+func (f *InstanceFilter) match(sess *awsSession, identifier, engine, arn string) (bool, []string) {
+	if f.excludeInstanceRegex != nil && f.excludeInstanceRegex.MatchString(identifier) {
+		return false, nil
+	}
+
+	if len(f.includeEngines) > 0 {
+		if _, ok := f.includeEngines[strings.ToLower(engine)]; !ok {
+			return false, nil
+		}
+	}
+
+	return f.matchTags(f.tagsForARN(sess, arn))
+}
+
+// matchTags applies the include-tag requirements and builds the label
+// values for labelTagKeys against an already-fetched tag set. Split out
+// from Match so the tag-matching precedence can be unit tested without an
+// AWS client.
+func (f *InstanceFilter) matchTags(tags map[string]string) (bool, []string) {
+	for _, tf := range f.includeTags {
+		if tags[tf.Key] != tf.Value {
+			return false, nil
+		}
+	}
+
+	labelValues := make([]string, len(f.labelTagKeys))
+	for i, key := range f.labelTagKeys {
+		labelValues[i] = tags[key]
+	}
+
+	return true, labelValues
+}
+
+// tagsForARN returns a resource's tags, fetching them via
+// ListTagsForResource on first use and caching the result by ARN. It
+// works for any RDS resource ARN (instance or cluster), since
+// ListTagsForResource isn't instance-specific.
+func (f *InstanceFilter) tagsForARN(sess *awsSession, arn string) map[string]string {
+	if len(f.includeTags) == 0 && len(f.labelTagKeys) == 0 {
+		return nil
+	}
+
+	f.mu.Lock()
+	if tags, ok := f.tagCache[arn]; ok {
+		f.mu.Unlock()
+		return tags
+	}
+	f.mu.Unlock()
+
+	resp, err := sess.rdsSvc.ListTagsForResource(context.TODO(), &rds.ListTagsForResourceInput{
+		ResourceName: aws.String(arn),
+	})
+	if err != nil {
+		log.Printf("unable to list tags for %s: %v", arn, err)
+		return nil
+	}
+
+	tags := make(map[string]string, len(resp.TagList))
+	for _, tag := range resp.TagList {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	f.mu.Lock()
+	f.tagCache[arn] = tags
+	f.mu.Unlock()
+
+	return tags
+}
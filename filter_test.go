@@ -0,0 +1,153 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+func TestInstanceFilterMatch(t *testing.T) {
+	tests := []struct {
+		name                 string
+		excludeInstanceRegex string
+		includeEngines       []string
+		instanceID           string
+		engine               string
+		wantInclude          bool
+	}{
+		{
+			name:        "no filters configured includes everything",
+			instanceID:  "prod-db-1",
+			engine:      "postgres",
+			wantInclude: true,
+		},
+		{
+			name:                 "excluded by regex",
+			excludeInstanceRegex: "^test-",
+			instanceID:           "test-db-1",
+			engine:               "mysql",
+			wantInclude:          false,
+		},
+		{
+			name:                 "not excluded by regex",
+			excludeInstanceRegex: "^test-",
+			instanceID:           "prod-db-1",
+			engine:               "mysql",
+			wantInclude:          true,
+		},
+		{
+			name:           "engine not in include list",
+			includeEngines: []string{"postgres"},
+			instanceID:     "prod-db-1",
+			engine:         "mysql",
+			wantInclude:    false,
+		},
+		{
+			name:           "engine in include list, case-insensitive",
+			includeEngines: []string{"MySQL"},
+			instanceID:     "prod-db-1",
+			engine:         "mysql",
+			wantInclude:    true,
+		},
+		{
+			name:                 "regex exclusion takes precedence over engine match",
+			excludeInstanceRegex: "^prod-",
+			includeEngines:       []string{"mysql"},
+			instanceID:           "prod-db-1",
+			engine:               "mysql",
+			wantInclude:          false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var excludeRegex *regexp.Regexp
+			if tt.excludeInstanceRegex != "" {
+				excludeRegex = regexp.MustCompile(tt.excludeInstanceRegex)
+			}
+
+			filter := NewInstanceFilter(nil, excludeRegex, tt.includeEngines, nil)
+			dbInstance := rdstypes.DBInstance{
+				DBInstanceIdentifier: aws.String(tt.instanceID),
+				Engine:               aws.String(tt.engine),
+			}
+
+			include, _ := filter.Match(nil, dbInstance)
+			if include != tt.wantInclude {
+				t.Errorf("Match() include = %v, want %v", include, tt.wantInclude)
+			}
+		})
+	}
+}
+
+func TestInstanceFilterMatchTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		includeTags  []tagFilter
+		labelTagKeys []string
+		tags         map[string]string
+		wantInclude  bool
+		wantLabels   []string
+	}{
+		{
+			name:        "required tag present",
+			includeTags: []tagFilter{{Key: "env", Value: "prod"}},
+			tags:        map[string]string{"env": "prod"},
+			wantInclude: true,
+			wantLabels:  []string{},
+		},
+		{
+			name:        "required tag missing",
+			includeTags: []tagFilter{{Key: "env", Value: "prod"}},
+			tags:        map[string]string{"env": "staging"},
+			wantInclude: false,
+			wantLabels:  nil,
+		},
+		{
+			name:        "required tag absent entirely",
+			includeTags: []tagFilter{{Key: "env", Value: "prod"}},
+			tags:        map[string]string{},
+			wantInclude: false,
+			wantLabels:  nil,
+		},
+		{
+			name:         "label tag keys propagate values in order",
+			labelTagKeys: []string{"env", "team"},
+			tags:         map[string]string{"env": "prod", "team": "payments"},
+			wantInclude:  true,
+			wantLabels:   []string{"prod", "payments"},
+		},
+		{
+			name:         "missing label tag key yields empty value",
+			labelTagKeys: []string{"env", "team"},
+			tags:         map[string]string{"env": "prod"},
+			wantInclude:  true,
+			wantLabels:   []string{"prod", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewInstanceFilter(tt.includeTags, nil, nil, tt.labelTagKeys)
+
+			include, labels := filter.matchTags(tt.tags)
+			if include != tt.wantInclude {
+				t.Fatalf("matchTags() include = %v, want %v", include, tt.wantInclude)
+			}
+			if !include {
+				return
+			}
+
+			if len(labels) != len(tt.wantLabels) {
+				t.Fatalf("matchTags() labels = %v, want %v", labels, tt.wantLabels)
+			}
+			for i, v := range labels {
+				if v != tt.wantLabels[i] {
+					t.Errorf("matchTags() labels[%d] = %q, want %q", i, v, tt.wantLabels[i])
+				}
+			}
+		})
+	}
+}
@@ -2,157 +2,83 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
-	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
-	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var (
-	maxAllocatedStorage = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "rds_max_allocated_storage_gigabytes",
-			Help: "Maximum storage (in gigabytes) that RDS instance can auto-scale to.",
-		},
-		[]string{"instance"},
-	)
-
-	currentUsage = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "rds_current_usage_gigabytes",
-			Help: "Current storage usage of the RDS instance in gigabytes.",
-		},
-		[]string{"instance"},
-	)
-)
-
-func init() {
-	prometheus.MustRegister(maxAllocatedStorage)
-	prometheus.MustRegister(currentUsage)
-}
-
-func loadAWSConfig() aws.Config {
-	region := "us-east-1"
-	if os.Getenv("AWS_REGION") != "" {
-		region = os.Getenv("AWS_REGION")
-	}
-
-	cfgOptions := []func(*config.LoadOptions) error{
-		config.WithRegion(region),
-	}
-
-	if os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "" {
-		// get the session if one is set in the environment
-		session := ""
-		if os.Getenv("AWS_SESSION_TOKEN") != "" {
-			session = os.Getenv("AWS_SESSION_TOKEN")
+// loadSessions returns the AWS sessions to scrape. When --config is set,
+// it loads a multi-account/multi-region pool from the YAML config file;
+// otherwise it falls back to a single session built from the environment,
+// preserving the exporter's original single-account behavior.
+func loadSessions(ctx context.Context, configPath string) []*awsSession {
+	if configPath != "" {
+		cfg, err := loadConfigFile(configPath)
+		if err != nil {
+			log.Fatalf("unable to load config file: %v", err)
 		}
-
-		cfgOptions = append(cfgOptions, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-			os.Getenv("AWS_ACCESS_KEY_ID"),
-			os.Getenv("AWS_SECRET_ACCESS_KEY"),
-			session,
-		)))
+		return buildSessions(ctx, cfg)
 	}
 
-	// Load the AWS config with the provided options
-	cfg, err := config.LoadDefaultConfig(context.TODO(), cfgOptions...)
+	sess, err := newAWSSession(ctx, SessionConfig{Region: os.Getenv("AWS_REGION")})
 	if err != nil {
-		log.Fatalf("unable to load SDK config: %v", err)
+		log.Fatalf("unable to build default AWS session: %v", err)
 	}
-
-	return cfg
+	return []*awsSession{sess}
 }
 
-func main() {
-	log.Println("Starting application...")
-
-	// Load the AWS Configuration
-	cfg := loadAWSConfig()
-
-	// Create an Amazon RDS and CloudWatch service client
-	rdsSvc := rds.NewFromConfig(cfg)
-	cwSvc := cloudwatch.NewFromConfig(cfg)
-
-	// Start Prometheus HTTP server
-	go func() {
-		http.Handle("/metrics", promhttp.Handler())
-		log.Fatal(http.ListenAndServe(":9761", nil))
-	}()
-
-	// Continuously update metrics
-	for {
-		updateMetrics(rdsSvc, cwSvc)
-		time.Sleep(5 * time.Minute) // Adjust the frequency of updates as needed
+// splitNonEmpty splits a comma-separated flag value, dropping empty
+// entries so "" and trailing commas don't produce spurious list items.
+func splitNonEmpty(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			result = append(result, part)
+		}
 	}
+	return result
 }
 
-func updateMetrics(rdsSvc *rds.Client, cwSvc *cloudwatch.Client) {
-	log.Println("Updating metrics...")
-
-	// Send the request, and get the response for RDS instances
-	resp, err := rdsSvc.DescribeDBInstances(context.TODO(), &rds.DescribeDBInstancesInput{})
-	if err != nil {
-		log.Printf("unable to describe DB instances: %v", err)
-		return
-	}
-
-	// Process each DB instance
-	for _, dbInstance := range resp.DBInstances {
-		instanceID := aws.ToString(dbInstance.DBInstanceIdentifier)
+func main() {
+	log.Println("Starting application...")
 
-		// Get FreeStorageSpace from CloudWatch
-		metricData, err := cwSvc.GetMetricData(context.TODO(), &cloudwatch.GetMetricDataInput{
-			StartTime: aws.Time(time.Now().Add(-3 * time.Hour)),
-			EndTime:   aws.Time(time.Now()),
-			MetricDataQueries: []types.MetricDataQuery{
-				{
-					Id: aws.String("m1"),
-					MetricStat: &types.MetricStat{
-						Metric: &types.Metric{
-							Namespace:  aws.String("AWS/RDS"),
-							MetricName: aws.String("FreeStorageSpace"),
-							Dimensions: []types.Dimension{
-								{
-									Name:  aws.String("DBInstanceIdentifier"),
-									Value: dbInstance.DBInstanceIdentifier,
-								},
-							},
-						},
-						Period: aws.Int32(3600),
-						Stat:   aws.String("Average"),
-					},
-				},
-			},
-		})
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file describing the AWS accounts/regions to scrape")
+	cacheTTL := flag.Duration("cache-ttl", time.Minute, "how long fetched RDS/CloudWatch metrics are reused between scrapes")
+	excludeInstanceRegex := flag.String("exclude-instance-regex", "", "skip any RDS instance whose identifier matches this regex")
+	includeEngine := flag.String("include-engine", "", "comma-separated list of engines to include (e.g. postgres,mysql); empty means all")
+	labelTags := flag.String("label-tags", "", "comma-separated list of tag keys to propagate as Prometheus labels (e.g. env,team)")
+	var includeTags tagFilterFlag
+	flag.Var(&includeTags, "include-tag", "only scrape instances carrying this tag, as key=value (repeatable)")
+	flag.Parse()
+
+	var excludeRegex *regexp.Regexp
+	if *excludeInstanceRegex != "" {
+		var err error
+		excludeRegex, err = regexp.Compile(*excludeInstanceRegex)
 		if err != nil {
-			log.Printf("unable to get metric data for instance %s: %v", instanceID, err)
-			continue
+			log.Fatalf("invalid --exclude-instance-regex: %v", err)
 		}
+	}
 
-		// Assume there's data and calculate usage
-		if len(metricData.MetricDataResults) > 0 && len(metricData.MetricDataResults[0].Values) > 0 {
-			totalSpace := aws.ToInt32(dbInstance.AllocatedStorage) //* 1073741824 // GB to Bytes
-
-			currentUsage.WithLabelValues(instanceID).Set(float64(totalSpace))
+	filter := NewInstanceFilter(includeTags, excludeRegex, splitNonEmpty(*includeEngine), splitNonEmpty(*labelTags))
 
-			if dbInstance.MaxAllocatedStorage != nil {
-				maxAllocatedBytes := aws.ToInt32(dbInstance.MaxAllocatedStorage) //* 1073741824 // GB to Bytes
-				maxAllocatedStorage.WithLabelValues(instanceID).Set(float64(maxAllocatedBytes))
-			}
-		} else {
-			log.Printf("no metric data found for instance %s", instanceID)
-		}
+	ctx := context.Background()
+	sessions := loadSessions(ctx, *configPath)
+	if len(sessions) == 0 {
+		log.Fatal("no usable AWS sessions configured")
 	}
 
-	log.Println("Metrics updated successfully.")
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewRDSStorageCollector(sessions, *cacheTTL, filter))
+
+	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	log.Fatal(http.ListenAndServe(":9761", nil))
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// awsSession bundles the clients and identifying labels (region, account
+// ID) needed to scrape a single AWS account/region pair.
+type awsSession struct {
+	Region    string
+	AccountID string
+
+	rdsSvc *rds.Client
+	cwSvc  *cloudwatch.Client
+}
+
+// newAWSSession builds an awsSession from a SessionConfig, assuming
+// RoleARN via STS when one is set and resolving the account ID via
+// GetCallerIdentity.
+func newAWSSession(ctx context.Context, sc SessionConfig) (*awsSession, error) {
+	cfg := loadAWSConfigFor(ctx, sc)
+
+	if sc.RoleARN != "" {
+		stsSvc := sts.NewFromConfig(cfg)
+		provider := stscreds.NewAssumeRoleProvider(stsSvc, sc.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+			if sc.ExternalID != "" {
+				o.ExternalID = aws.String(sc.ExternalID)
+			}
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve account ID for region %s: %w", sc.Region, err)
+	}
+
+	return &awsSession{
+		Region:    sc.Region,
+		AccountID: aws.ToString(identity.Account),
+		rdsSvc:    rds.NewFromConfig(cfg),
+		cwSvc:     cloudwatch.NewFromConfig(cfg),
+	}, nil
+}
+
+// loadAWSConfigFor loads an aws.Config for a single SessionConfig,
+// preferring its explicit profile/region but falling back to the same
+// environment-derived credentials as loadAWSConfig.
+func loadAWSConfigFor(ctx context.Context, sc SessionConfig) aws.Config {
+	region := sc.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	cfgOptions := []func(*config.LoadOptions) error{
+		config.WithRegion(region),
+	}
+
+	if sc.Profile != "" {
+		cfgOptions = append(cfgOptions, config.WithSharedConfigProfile(sc.Profile))
+	} else if os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "" {
+		session := os.Getenv("AWS_SESSION_TOKEN")
+		cfgOptions = append(cfgOptions, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			os.Getenv("AWS_ACCESS_KEY_ID"),
+			os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			session,
+		)))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, cfgOptions...)
+	if err != nil {
+		log.Fatalf("unable to load SDK config for region %s: %v", region, err)
+	}
+
+	return cfg
+}
+
+// buildSessions resolves every SessionConfig in cfg into an awsSession,
+// logging (but not failing on) any session that can't be established so
+// that one broken account doesn't prevent the others from scraping.
+func buildSessions(ctx context.Context, cfg *Config) []*awsSession {
+	sessions := make([]*awsSession, 0, len(cfg.Sessions))
+
+	for _, sc := range cfg.Sessions {
+		sess, err := newAWSSession(ctx, sc)
+		if err != nil {
+			log.Printf("skipping session for region %s: %v", sc.Region, err)
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+
+	return sessions
+}